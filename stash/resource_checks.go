@@ -0,0 +1,260 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+const maxAnnotationsPerCall = 1000
+
+// Checks returns a client for reading and writing Code Insights reports on
+// commits in this repository.
+func (r *userRepository) Checks() gitprovider.ChecksClient {
+	return r.checks
+}
+
+// ChecksClient implements the gitprovider.ChecksClient interface.
+var _ gitprovider.ChecksClient = &ChecksClient{}
+
+// ChecksClient operates on the Code Insights reports of a specific
+// repository, as exposed by Bitbucket Server's
+// "/rest/insights/1.0/projects/{projectKey}/repos/{repoSlug}/commits/{commitId}/reports"
+// API.
+type ChecksClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// UpsertReport creates the report for the given commit, or replaces it in
+// full (including its annotations) if a report with the same key already
+// exists, matching the semantics of Bitbucket Server's Code Insights API.
+func (c *ChecksClient) UpsertReport(ctx context.Context, sha string, report gitprovider.Report) (*gitprovider.Report, error) {
+	if err := report.ValidateInfo(); err != nil {
+		return nil, err
+	}
+	apiObj, err := c.client.UpsertReport(ctx, c.ownerID(ctx), c.ref.GetRepository(), sha, reportToAPI(&report))
+	if err != nil {
+		return nil, err
+	}
+	return reportFromAPI(apiObj), nil
+}
+
+// DeleteReport deletes the report identified by reportKey for the given commit.
+// ErrNotFound is returned if no such report exists.
+func (c *ChecksClient) DeleteReport(ctx context.Context, sha, reportKey string) error {
+	return c.client.DeleteReport(ctx, c.ownerID(ctx), c.ref.GetRepository(), sha, reportKey)
+}
+
+// ListReports lists all Code Insights reports attached to the given commit.
+func (c *ChecksClient) ListReports(ctx context.Context, sha string) ([]*gitprovider.Report, error) {
+	apiObjs, err := c.client.ListReports(ctx, c.ownerID(ctx), c.ref.GetRepository(), sha)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]*gitprovider.Report, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		reports = append(reports, reportFromAPI(apiObj))
+	}
+	return reports, nil
+}
+
+// AddAnnotations attaches annotations to the report identified by reportKey
+// for the given commit. The Code Insights API accepts at most 1000
+// annotations per call, so the provided annotations are chunked transparently.
+func (c *ChecksClient) AddAnnotations(ctx context.Context, sha, reportKey string, annotations []gitprovider.Annotation) error {
+	for start := 0; start < len(annotations); start += maxAnnotationsPerCall {
+		end := start + maxAnnotationsPerCall
+		if end > len(annotations) {
+			end = len(annotations)
+		}
+		chunk := make([]*Annotation, 0, end-start)
+		for _, a := range annotations[start:end] {
+			chunk = append(chunk, annotationToAPI(&a))
+		}
+		if err := c.client.AddAnnotations(ctx, c.ownerID(ctx), c.ref.GetRepository(), sha, reportKey, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ChecksClient) ownerID(ctx context.Context) string {
+	return c.client.getOwnerID(ctx, c.ref.GetIdentity())
+}
+
+// Report is the Bitbucket Server API representation of a Code Insights report.
+type Report struct {
+	Key     string            `json:"key"`
+	Title   string            `json:"title"`
+	Details string            `json:"details,omitempty"`
+	Result  string            `json:"result,omitempty"`
+	Data    []ReportDataField `json:"data,omitempty"`
+}
+
+// ReportDataField is a single typed field surfaced in the Code Insights UI.
+type ReportDataField struct {
+	Title string      `json:"title"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// Annotation is the Bitbucket Server API representation of a single finding
+// attached to a Code Insights report.
+type Annotation struct {
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+	Severity string `json:"severity,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Link     string `json:"link,omitempty"`
+}
+
+// validateReportAPI validates that the report returned by the server carries
+// the fields required to construct a gitprovider.Report.
+func validateReportAPI(apiObj *Report) error {
+	if apiObj == nil {
+		return gitprovider.ErrNotFound
+	}
+	if apiObj.Key == "" {
+		return fmt.Errorf("validation: Stash.Report.Key is required")
+	}
+	if apiObj.Title == "" {
+		return fmt.Errorf("validation: Stash.Report.Title is required")
+	}
+	return nil
+}
+
+func reportFromAPI(apiObj *Report) *gitprovider.Report {
+	report := &gitprovider.Report{
+		Key:     apiObj.Key,
+		Title:   apiObj.Title,
+		Details: apiObj.Details,
+		Result:  gitprovider.ReportResult(apiObj.Result),
+	}
+	for _, field := range apiObj.Data {
+		report.Data = append(report.Data, gitprovider.ReportDataField{
+			Title: field.Title,
+			Type:  gitprovider.ReportDataType(field.Type),
+			Value: field.Value,
+		})
+	}
+	return report
+}
+
+func reportToAPI(report *gitprovider.Report) *Report {
+	apiObj := &Report{
+		Key:     report.Key,
+		Title:   report.Title,
+		Details: report.Details,
+		Result:  string(report.Result),
+	}
+	for _, field := range report.Data {
+		apiObj.Data = append(apiObj.Data, ReportDataField{
+			Title: field.Title,
+			Type:  string(field.Type),
+			Value: field.Value,
+		})
+	}
+	return apiObj
+}
+
+func annotationToAPI(annotation *gitprovider.Annotation) *Annotation {
+	return &Annotation{
+		Path:     annotation.Path,
+		Line:     annotation.Line,
+		Message:  annotation.Message,
+		Severity: string(annotation.Severity),
+		Type:     string(annotation.Type),
+		Link:     annotation.Link,
+	}
+}
+
+// stashReports is a thin wrapper around the Bitbucket Server Code Insights
+// REST resource, used internally by stashClientImpl.
+type stashReports struct {
+	c        stashClient
+	ownerID  string
+	repoSlug string
+	reports  []*Report
+}
+
+// NewStashReports creates a client for the Code Insights resource of a
+// single repository.
+func NewStashReports(c stashClient, ownerID, repoSlug string) *stashReports {
+	return &stashReports{c: c, ownerID: ownerID, repoSlug: repoSlug}
+}
+
+func (s *stashReports) getReports() []*Report {
+	return s.reports
+}
+
+// Upsert is a wrapper for "PUT .../commits/{commitId}/reports/{reportKey}".
+func (s *stashReports) Upsert(ctx context.Context, commitSHA string, req *Report) (*Report, error) {
+	var apiObj Report
+	err := s.c.Client().Put(ctx, s.reportPath(commitSHA, req.Key), req, &apiObj)
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return &apiObj, nil
+}
+
+// Delete is a wrapper for "DELETE .../commits/{commitId}/reports/{reportKey}".
+func (s *stashReports) Delete(ctx context.Context, commitSHA, reportKey string) error {
+	err := s.c.Client().Delete(ctx, s.reportPath(commitSHA, reportKey), nil)
+	if err != nil {
+		return handleHTTPError(err)
+	}
+	return nil
+}
+
+// List is a wrapper for "GET .../commits/{commitId}/reports".
+func (s *stashReports) List(ctx context.Context, commitSHA string, opts *ListOptions) (*Paging, error) {
+	var page struct {
+		Paging
+		Values []*Report `json:"values"`
+	}
+	err := s.c.Client().Get(ctx, s.reportsPath(commitSHA), opts, &page)
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	s.reports = page.Values
+	return &page.Paging, nil
+}
+
+// AddAnnotations is a wrapper for "POST .../commits/{commitId}/reports/{reportKey}/annotations".
+func (s *stashReports) AddAnnotations(ctx context.Context, commitSHA, reportKey string, annotations []*Annotation) error {
+	req := struct {
+		Annotations []*Annotation `json:"annotations"`
+	}{Annotations: annotations}
+	err := s.c.Client().Post(ctx, s.reportPath(commitSHA, reportKey)+"/annotations", req, nil)
+	if err != nil {
+		return handleHTTPError(err)
+	}
+	return nil
+}
+
+func (s *stashReports) reportsPath(commitSHA string) string {
+	return fmt.Sprintf("insights/1.0/projects/%s/repos/%s/commits/%s/reports", s.ownerID, s.repoSlug, commitSHA)
+}
+
+func (s *stashReports) reportPath(commitSHA, reportKey string) string {
+	return fmt.Sprintf("%s/%s", s.reportsPath(commitSHA), reportKey)
+}
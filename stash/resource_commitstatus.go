@@ -0,0 +1,224 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// Statuses returns a client for reading and writing the build statuses of
+// commits in this repository.
+func (c *CommitClient) Statuses() gitprovider.CommitStatusClient {
+	return &CommitStatusClient{
+		clientContext: c.clientContext,
+		ref:           c.ref,
+	}
+}
+
+// CommitStatusClient implements the gitprovider.CommitStatusClient interface.
+var _ gitprovider.CommitStatusClient = &CommitStatusClient{}
+
+// CommitStatusClient operates on the build statuses of a specific repository,
+// as exposed by Bitbucket Server's
+// "/rest/build-status/1.0/commits/{commitId}" API.
+type CommitStatusClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Create creates a build status for the given commit. If a status with the
+// same key already exists for the commit, it is overwritten; Bitbucket
+// Server's build-status API always upserts on (commit, key).
+func (c *CommitStatusClient) Create(ctx context.Context, sha string, status gitprovider.CommitStatusInfo) (*gitprovider.CommitStatus, error) {
+	if err := status.ValidateInfo(); err != nil {
+		return nil, err
+	}
+	apiObj, err := c.client.CreateCommitStatus(ctx, c.client.getOwnerID(ctx, c.ref.GetIdentity()), c.ref.GetRepository(), sha, commitStatusInfoToAPI(&status))
+	if err != nil {
+		return nil, err
+	}
+	return commitStatusFromAPI(apiObj), nil
+}
+
+// List lists all the build statuses that have been posted for the given commit.
+func (c *CommitStatusClient) List(ctx context.Context, sha string) ([]*gitprovider.CommitStatus, error) {
+	apiObjs, err := c.client.ListCommitStatuses(ctx, c.client.getOwnerID(ctx, c.ref.GetIdentity()), c.ref.GetRepository(), sha)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]*gitprovider.CommitStatus, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		statuses = append(statuses, commitStatusFromAPI(apiObj))
+	}
+	return statuses, nil
+}
+
+// Get retrieves the build status uniquely identified by key for the given commit.
+// ErrNotFound is returned if no status with the given key has been posted.
+func (c *CommitStatusClient) Get(ctx context.Context, sha, key string) (*gitprovider.CommitStatus, error) {
+	statuses, err := c.List(ctx, sha)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		if status.Key == key {
+			return status, nil
+		}
+	}
+	return nil, gitprovider.ErrNotFound
+}
+
+// Reconcile makes sure that a build status with the given key for the given
+// commit reflects the desired state in req.
+//
+// If a status with this (sha, key) doesn't exist under the hood, it is
+// created (actionTaken == true).
+// If a status with this (sha, key) doesn't equal the actual state, it is
+// re-posted, overwriting the previous one (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+func (c *CommitStatusClient) Reconcile(ctx context.Context, sha string, req gitprovider.CommitStatusInfo) (*gitprovider.CommitStatus, bool, error) {
+	actual, err := c.Get(ctx, sha, req.Key)
+	if err != nil {
+		if err == gitprovider.ErrNotFound {
+			status, err := c.Create(ctx, sha, req)
+			return status, true, err
+		}
+		return nil, false, err
+	}
+
+	actualInfo := commitStatusInfoFromAPI(&CommitStatus{
+		Key:         actual.Key,
+		State:       string(actual.State),
+		URL:         actual.URL,
+		Description: actual.Description,
+	})
+	if actualInfo == req {
+		return actual, false, nil
+	}
+
+	status, err := c.Create(ctx, sha, req)
+	return status, true, err
+}
+
+// CommitStatus is the Bitbucket Server API representation of a build status
+// posted for a commit.
+type CommitStatus struct {
+	Key         string `json:"key"`
+	Name        string `json:"name,omitempty"`
+	URL         string `json:"url"`
+	State       string `json:"state"`
+	Description string `json:"description,omitempty"`
+	DateAdded   int64  `json:"dateAdded,omitempty"`
+}
+
+// validateCommitStatusAPI validates that the commit status returned by the
+// server carries the fields required to construct a gitprovider.CommitStatus.
+func validateCommitStatusAPI(apiObj *CommitStatus) error {
+	if apiObj == nil {
+		return gitprovider.ErrNotFound
+	}
+	if apiObj.Key == "" {
+		return fmt.Errorf("validation: Stash.CommitStatus.Key is required")
+	}
+	if apiObj.State == "" {
+		return fmt.Errorf("validation: Stash.CommitStatus.State is required")
+	}
+	if apiObj.URL == "" {
+		return fmt.Errorf("validation: Stash.CommitStatus.URL is required")
+	}
+	return nil
+}
+
+func commitStatusFromAPI(apiObj *CommitStatus) *gitprovider.CommitStatus {
+	return &gitprovider.CommitStatus{
+		Key:         apiObj.Key,
+		Name:        apiObj.Name,
+		URL:         apiObj.URL,
+		State:       gitprovider.CommitStatusState(apiObj.State),
+		Description: apiObj.Description,
+	}
+}
+
+func commitStatusInfoFromAPI(apiObj *CommitStatus) gitprovider.CommitStatusInfo {
+	return gitprovider.CommitStatusInfo{
+		Key:         apiObj.Key,
+		State:       gitprovider.CommitStatusState(apiObj.State),
+		URL:         apiObj.URL,
+		Description: apiObj.Description,
+	}
+}
+
+func commitStatusInfoToAPI(info *gitprovider.CommitStatusInfo) *CommitStatus {
+	return &CommitStatus{
+		// gitprovider.CommitStatusInfo has no display-name field of its own,
+		// so Name is left unset rather than faked from Key.
+		Key:         info.Key,
+		URL:         info.URL,
+		State:       string(info.State),
+		Description: info.Description,
+	}
+}
+
+// stashCommitStatuses is a thin wrapper around the Bitbucket Server
+// build-status REST resource, used internally by stashClientImpl.
+type stashCommitStatuses struct {
+	c        stashClient
+	ownerID  string
+	repoSlug string
+	statuses []*CommitStatus
+}
+
+// NewStashCommitStatuses creates a client for the build-status resource of a
+// single repository.
+func NewStashCommitStatuses(c stashClient, ownerID, repoSlug string) *stashCommitStatuses {
+	return &stashCommitStatuses{c: c, ownerID: ownerID, repoSlug: repoSlug}
+}
+
+func (s *stashCommitStatuses) getCommitStatuses() []*CommitStatus {
+	return s.statuses
+}
+
+// Create is a wrapper for "POST /rest/build-status/1.0/commits/{commitId}".
+// The endpoint responds 204 No Content on success, so there is no body to
+// decode a *CommitStatus out of; echo back what was posted instead.
+func (s *stashCommitStatuses) Create(ctx context.Context, commitSHA string, req *CommitStatus) (*CommitStatus, error) {
+	if err := s.c.Client().Post(ctx, commitStatusPath(commitSHA), req, nil); err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return req, nil
+}
+
+// List is a wrapper for "GET /rest/build-status/1.0/commits/{commitId}".
+func (s *stashCommitStatuses) List(ctx context.Context, commitSHA string, opts *ListOptions) (*Paging, error) {
+	var page struct {
+		Paging
+		Values []*CommitStatus `json:"values"`
+	}
+	err := s.c.Client().Get(ctx, commitStatusPath(commitSHA), opts, &page)
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	s.statuses = page.Values
+	return &page.Paging, nil
+}
+
+func commitStatusPath(commitSHA string) string {
+	return fmt.Sprintf("build-status/1.0/commits/%s", commitSHA)
+}
@@ -0,0 +1,36 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+)
+
+// GetFiltered is a wrapper for
+// "GET /rest/api/1.0/admin/groups/more-members?context={group}&filter={user}".
+// It reuses the same paged more-members resource and URL-encoding that List
+// uses for groupID, and layers the filter on top via ListOptions, narrowing
+// the result set server-side to the given user and avoiding a full
+// ListGroupMembers scan.
+func (s *stashGroupMembers) GetFiltered(ctx context.Context, groupID, userID string) (*GroupMembers, error) {
+	opts := &ListOptions{Filter: userID}
+	paging, err := s.List(ctx, groupID, opts)
+	if err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return &GroupMembers{Paging: *paging, Values: s.getGroupMembers()}, nil
+}
@@ -0,0 +1,301 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// Hooks returns a client for managing the repository webhooks of this repository.
+func (r *userRepository) Hooks() gitprovider.RepositoryHookClient {
+	return r.hooks
+}
+
+// RepositoryHookClient implements the gitprovider.RepositoryHookClient interface.
+var _ gitprovider.RepositoryHookClient = &RepositoryHookClient{}
+
+// RepositoryHookClient operates on the webhooks of a specific repository, as
+// exposed by Bitbucket Server's
+// "/rest/api/1.0/projects/{projectKey}/repos/{repoSlug}/webhooks" API.
+type RepositoryHookClient struct {
+	*clientContext
+	ref gitprovider.RepositoryRef
+}
+
+// Create creates a webhook with the given configuration.
+func (c *RepositoryHookClient) Create(ctx context.Context, req gitprovider.HookInfo) (*gitprovider.Hook, error) {
+	if err := req.ValidateInfo(); err != nil {
+		return nil, err
+	}
+	apiObj, err := c.client.CreateHook(ctx, c.ownerID(ctx), c.ref.GetRepository(), hookInfoToAPI(&req))
+	if err != nil {
+		return nil, err
+	}
+	return hookFromAPI(apiObj), nil
+}
+
+// Get retrieves the webhook identified by hookID.
+// ErrNotFound is returned if the webhook doesn't exist.
+func (c *RepositoryHookClient) Get(ctx context.Context, hookID string) (*gitprovider.Hook, error) {
+	apiObj, err := c.client.GetHook(ctx, c.ownerID(ctx), c.ref.GetRepository(), hookID)
+	if err != nil {
+		return nil, err
+	}
+	return hookFromAPI(apiObj), nil
+}
+
+// List lists all the webhooks configured on this repository.
+func (c *RepositoryHookClient) List(ctx context.Context) ([]*gitprovider.Hook, error) {
+	apiObjs, err := c.client.ListHooks(ctx, c.ownerID(ctx), c.ref.GetRepository())
+	if err != nil {
+		return nil, err
+	}
+	hooks := make([]*gitprovider.Hook, 0, len(apiObjs))
+	for _, apiObj := range apiObjs {
+		hooks = append(hooks, hookFromAPI(apiObj))
+	}
+	return hooks, nil
+}
+
+// Update updates the webhook identified by hookID to match req.
+func (c *RepositoryHookClient) Update(ctx context.Context, hookID string, req gitprovider.HookInfo) (*gitprovider.Hook, error) {
+	if err := req.ValidateInfo(); err != nil {
+		return nil, err
+	}
+	apiObj, err := c.client.UpdateHook(ctx, c.ownerID(ctx), c.ref.GetRepository(), hookID, hookInfoToAPI(&req))
+	if err != nil {
+		return nil, err
+	}
+	return hookFromAPI(apiObj), nil
+}
+
+// Delete deletes the webhook identified by hookID.
+// ErrNotFound is returned if the webhook doesn't exist.
+func (c *RepositoryHookClient) Delete(ctx context.Context, hookID string) error {
+	return c.client.DeleteHook(ctx, c.ownerID(ctx), c.ref.GetRepository(), hookID)
+}
+
+// Reconcile makes sure a webhook matching req's URL exists on this repository
+// and is configured the way req describes it.
+//
+// If no webhook with this URL exists under the hood, it is created
+// (actionTaken == true).
+// If a webhook with this URL exists but doesn't match req, it is updated
+// (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+// A webhook is compared on URL, Active and Events only (see hooksEqual); a
+// change to req.Secret alone does not trigger an update.
+func (c *RepositoryHookClient) Reconcile(ctx context.Context, req gitprovider.HookInfo) (*gitprovider.Hook, bool, error) {
+	hooks, err := c.List(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var actual *gitprovider.Hook
+	for _, hook := range hooks {
+		if hook.URL == req.URL {
+			actual = hook
+			break
+		}
+	}
+
+	if actual == nil {
+		hook, err := c.Create(ctx, req)
+		return hook, true, err
+	}
+
+	if hooksEqual(actual, req) {
+		return actual, false, nil
+	}
+
+	hook, err := c.Update(ctx, actual.ID, req)
+	return hook, true, err
+}
+
+// Plan reports whether a webhook matching req's URL would be created,
+// updated, or is already present, without creating or updating anything.
+// It lets a whole-repository Plan compose webhooks into its sub-resource
+// plans.
+func (c *RepositoryHookClient) Plan(ctx context.Context, req gitprovider.HookInfo) (*gitprovider.ReconcilePlan, error) {
+	hooks, err := c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hook := range hooks {
+		if hook.URL != req.URL {
+			continue
+		}
+		if hooksEqual(hook, req) {
+			return &gitprovider.ReconcilePlan{Action: gitprovider.ReconcileActionNoop}, nil
+		}
+		return &gitprovider.ReconcilePlan{Action: gitprovider.ReconcileActionUpdate}, nil
+	}
+	return &gitprovider.ReconcilePlan{Action: gitprovider.ReconcileActionCreate}, nil
+}
+
+func (c *RepositoryHookClient) ownerID(ctx context.Context) string {
+	return c.client.getOwnerID(ctx, c.ref.GetIdentity())
+}
+
+// hooksEqual reports whether actual already matches req, comparing Events as
+// a set rather than in order: Bitbucket Server doesn't guarantee it returns
+// events in the order they were submitted, and comparing positionally would
+// make Reconcile re-update a hook that's already correct every time the
+// server happens to reorder them. Only URL, Active and Events are compared;
+// req.Secret is not.
+func hooksEqual(actual *gitprovider.Hook, req gitprovider.HookInfo) bool {
+	if actual.URL != req.URL || actual.Active != req.Active {
+		return false
+	}
+	if len(actual.Events) != len(req.Events) {
+		return false
+	}
+	actualEvents := make(map[string]bool, len(actual.Events))
+	for _, event := range actual.Events {
+		actualEvents[event] = true
+	}
+	for _, event := range req.Events {
+		if !actualEvents[event] {
+			return false
+		}
+	}
+	return true
+}
+
+// Hook is the Bitbucket Server API representation of a repository webhook.
+type Hook struct {
+	ID            int64    `json:"id,omitempty"`
+	Name          string   `json:"name,omitempty"`
+	URL           string   `json:"url"`
+	Events        []string `json:"events"`
+	Configuration struct {
+		Secret string `json:"secret,omitempty"`
+	} `json:"configuration,omitempty"`
+	Active bool `json:"active"`
+}
+
+// validateHookAPI validates that the hook returned by the server carries the
+// fields required to construct a gitprovider.Hook.
+func validateHookAPI(apiObj *Hook) error {
+	if apiObj == nil {
+		return gitprovider.ErrNotFound
+	}
+	if apiObj.URL == "" {
+		return fmt.Errorf("validation: Stash.Hook.URL is required")
+	}
+	if len(apiObj.Events) == 0 {
+		return fmt.Errorf("validation: Stash.Hook.Events is required")
+	}
+	return nil
+}
+
+func hookFromAPI(apiObj *Hook) *gitprovider.Hook {
+	return &gitprovider.Hook{
+		ID:     fmt.Sprintf("%d", apiObj.ID),
+		URL:    apiObj.URL,
+		Events: apiObj.Events,
+		Active: apiObj.Active,
+	}
+}
+
+func hookInfoToAPI(info *gitprovider.HookInfo) *Hook {
+	apiObj := &Hook{
+		URL:    info.URL,
+		Events: info.Events,
+		Active: info.Active,
+	}
+	apiObj.Configuration.Secret = info.Secret
+	return apiObj
+}
+
+// stashHooks is a thin wrapper around the Bitbucket Server webhooks REST
+// resource, used internally by stashClientImpl.
+type stashHooks struct {
+	c        stashClient
+	ownerID  string
+	repoSlug string
+	hooks    []*Hook
+}
+
+// NewStashHooks creates a client for the webhooks resource of a single repository.
+func NewStashHooks(c stashClient, ownerID, repoSlug string) *stashHooks {
+	return &stashHooks{c: c, ownerID: ownerID, repoSlug: repoSlug}
+}
+
+func (s *stashHooks) getHooks() []*Hook {
+	return s.hooks
+}
+
+func (s *stashHooks) hooksPath() string {
+	return fmt.Sprintf("api/1.0/projects/%s/repos/%s/webhooks", s.ownerID, s.repoSlug)
+}
+
+func (s *stashHooks) hookPath(hookID string) string {
+	return fmt.Sprintf("%s/%s", s.hooksPath(), hookID)
+}
+
+// Create is a wrapper for "POST .../webhooks".
+func (s *stashHooks) Create(ctx context.Context, req *Hook) (*Hook, error) {
+	var apiObj Hook
+	if err := s.c.Client().Post(ctx, s.hooksPath(), req, &apiObj); err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return &apiObj, nil
+}
+
+// Get is a wrapper for "GET .../webhooks/{webhookId}".
+func (s *stashHooks) Get(ctx context.Context, hookID string) (*Hook, error) {
+	var apiObj Hook
+	if err := s.c.Client().Get(ctx, s.hookPath(hookID), nil, &apiObj); err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return &apiObj, nil
+}
+
+// List is a wrapper for "GET .../webhooks".
+func (s *stashHooks) List(ctx context.Context, opts *ListOptions) (*Paging, error) {
+	var page struct {
+		Paging
+		Values []*Hook `json:"values"`
+	}
+	if err := s.c.Client().Get(ctx, s.hooksPath(), opts, &page); err != nil {
+		return nil, handleHTTPError(err)
+	}
+	s.hooks = page.Values
+	return &page.Paging, nil
+}
+
+// Update is a wrapper for "PUT .../webhooks/{webhookId}".
+func (s *stashHooks) Update(ctx context.Context, hookID string, req *Hook) (*Hook, error) {
+	var apiObj Hook
+	if err := s.c.Client().Put(ctx, s.hookPath(hookID), req, &apiObj); err != nil {
+		return nil, handleHTTPError(err)
+	}
+	return &apiObj, nil
+}
+
+// Delete is a wrapper for "DELETE .../webhooks/{webhookId}".
+func (s *stashHooks) Delete(ctx context.Context, hookID string) error {
+	if err := s.c.Client().Delete(ctx, s.hookPath(hookID), nil); err != nil {
+		return handleHTTPError(err)
+	}
+	return nil
+}
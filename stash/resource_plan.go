@@ -0,0 +1,277 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// Plan computes what Reconcile would do to bring this repository's backing
+// state in line with its current in-memory RepositoryInfo (as last set via
+// Set), without calling the server to create or update anything. It does
+// issue read-only requests: one to fetch the repository's actual state to
+// diff against, plus those of each sub-resource plan requested via opts.
+//
+// Unlike RepositoryInfo, deploy keys and webhooks aren't held in memory on
+// this object, so composing their plans alongside the repository's requires
+// their desired state to be passed explicitly via opts.
+func (r *userRepository) Plan(ctx context.Context, opts ...PlanOption) (*gitprovider.ReconcilePlan, error) {
+	ref := r.ref.(gitprovider.UserRepositoryRef)
+	plan, err := planRepository(ctx, r.c.client, addTilde(ref.UserLogin), ref.GetSlug(), &r.repository)
+	if err != nil {
+		return nil, err
+	}
+	return planSubResources(ctx, plan, resolvePlanOptions(opts), r.deployKeys, r.hooks, nil)
+}
+
+// Apply executes the action a previous call to Plan decided on, rather than
+// recomputing it: ReconcileActionCreate creates the repository,
+// ReconcileActionUpdate pushes the in-memory RepositoryInfo the plan was
+// diffed against, and ReconcileActionNoop does nothing.
+func (r *userRepository) Apply(ctx context.Context, plan *gitprovider.ReconcilePlan) (bool, error) {
+	switch plan.Action {
+	case gitprovider.ReconcileActionNoop:
+		return false, nil
+	case gitprovider.ReconcileActionCreate:
+		_, actionTaken, err := r.c.Reconcile(ctx, r.ref.(gitprovider.UserRepositoryRef), repositoryFromAPI(&r.repository))
+		return actionTaken, err
+	default: // ReconcileActionUpdate
+		return true, r.Update(ctx)
+	}
+}
+
+// Reconcile makes sure the desired state in this object (called "req" here) becomes
+// the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+//
+// Call Plan instead to preview what Reconcile would do without touching the
+// server.
+//
+// The internal API object will be overridden with the received server data if actionTaken == true.
+func (r *userRepository) Reconcile(ctx context.Context) (bool, error) {
+	_, actionTaken, err := r.c.Reconcile(ctx, r.ref.(gitprovider.UserRepositoryRef), repositoryFromAPI(&r.repository))
+
+	if err != nil {
+		// Log the error and return it
+		r.c.log.V(1).Error(err, "Error reconciling repository",
+			"org", r.Repository().GetIdentity(),
+			"repo", r.Repository().GetRepository(),
+			"actionTaken", actionTaken)
+		return actionTaken, err
+	}
+
+	return actionTaken, nil
+}
+
+// Plan computes what Reconcile would do to bring this repository's backing
+// state in line with its current in-memory RepositoryInfo (as last set via
+// Set), without calling the server to create or update anything. It does
+// issue read-only requests: one to fetch the repository's actual state to
+// diff against, plus those of each sub-resource plan requested via opts.
+//
+// Unlike RepositoryInfo, deploy keys, webhooks and team access aren't held
+// in memory on this object, so composing their plans alongside the
+// repository's requires their desired state to be passed explicitly via
+// opts.
+func (r *orgRepository) Plan(ctx context.Context, opts ...PlanOption) (*gitprovider.ReconcilePlan, error) {
+	ref := r.ref.(gitprovider.OrgRepositoryRef)
+	plan, err := planRepository(ctx, r.c.client, ref.Key(), ref.Slug(), &r.repository)
+	if err != nil {
+		return nil, err
+	}
+	return planSubResources(ctx, plan, resolvePlanOptions(opts), r.deployKeys, r.hooks, r.teamAccess)
+}
+
+// Apply executes the action a previous call to Plan decided on, rather than
+// recomputing it: ReconcileActionCreate creates the repository,
+// ReconcileActionUpdate pushes the in-memory RepositoryInfo the plan was
+// diffed against, and ReconcileActionNoop does nothing.
+func (r *orgRepository) Apply(ctx context.Context, plan *gitprovider.ReconcilePlan) (bool, error) {
+	switch plan.Action {
+	case gitprovider.ReconcileActionNoop:
+		return false, nil
+	case gitprovider.ReconcileActionCreate:
+		_, actionTaken, err := r.c.Reconcile(ctx, r.ref.(gitprovider.OrgRepositoryRef), repositoryFromAPI(&r.repository))
+		return actionTaken, err
+	default: // ReconcileActionUpdate
+		return true, r.Update(ctx)
+	}
+}
+
+// Reconcile makes sure the desired state in this object (called "req" here) becomes
+// the actual state in the backing Git provider.
+//
+// If req doesn't exist under the hood, it is created (actionTaken == true).
+// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
+// If req is already the actual state, this is a no-op (actionTaken == false).
+//
+// Call Plan instead to preview what Reconcile would do without touching the
+// server.
+//
+// The internal API object will be overridden with the received server data if actionTaken == true.
+func (r *orgRepository) Reconcile(ctx context.Context) (bool, error) {
+	_, actionTaken, err := r.c.Reconcile(ctx, r.ref.(gitprovider.OrgRepositoryRef), repositoryFromAPI(&r.repository))
+
+	if err != nil {
+		// Log the error and return it
+		r.c.log.V(1).Error(err, "Error reconciling repository",
+			"org", r.Repository().GetIdentity(),
+			"repo", r.Repository().GetRepository(),
+			"actionTaken", actionTaken)
+		return actionTaken, err
+	}
+
+	return actionTaken, nil
+}
+
+// planRepository builds a ReconcilePlan for a repository whose desired state
+// has already been folded into desired (via Set), comparing it against the
+// actual state fetched from the server.
+func planRepository(ctx context.Context, client stashClient, ownerID, repoSlug string, desired *Repository) (*gitprovider.ReconcilePlan, error) {
+	actual, err := get(ctx, client, ownerID, repoSlug)
+	if err != nil {
+		if err == gitprovider.ErrNotFound {
+			return &gitprovider.ReconcilePlan{Action: gitprovider.ReconcileActionCreate}, nil
+		}
+		return nil, err
+	}
+
+	diff := diffRepositoryInfo(repositoryFromAPI(actual), repositoryFromAPI(desired))
+	if len(diff) == 0 {
+		return &gitprovider.ReconcilePlan{Action: gitprovider.ReconcileActionNoop}, nil
+	}
+	return &gitprovider.ReconcilePlan{Action: gitprovider.ReconcileActionUpdate, Diff: diff}, nil
+}
+
+// diffRepositoryInfo returns the set of RepositoryInfo fields that differ
+// between actual and desired.
+func diffRepositoryInfo(actual, desired gitprovider.RepositoryInfo) []gitprovider.ReconcileFieldDiff {
+	var diff []gitprovider.ReconcileFieldDiff
+
+	if !stringPtrEqual(actual.Description, desired.Description) {
+		diff = append(diff, gitprovider.ReconcileFieldDiff{
+			Field:   "Description",
+			Actual:  stringPtrValue(actual.Description),
+			Desired: stringPtrValue(desired.Description),
+		})
+	}
+
+	actualVisibility, desiredVisibility := "", ""
+	if actual.Visibility != nil {
+		actualVisibility = string(*actual.Visibility)
+	}
+	if desired.Visibility != nil {
+		desiredVisibility = string(*desired.Visibility)
+	}
+	if actualVisibility != desiredVisibility {
+		diff = append(diff, gitprovider.ReconcileFieldDiff{
+			Field:   "Visibility",
+			Actual:  actualVisibility,
+			Desired: desiredVisibility,
+		})
+	}
+
+	return diff
+}
+
+// PlanOption supplies the desired state of a repository sub-resource to
+// Plan, so its plan can be composed into the repository's ReconcilePlan.
+// Sub-resources aren't held in memory on userRepository/orgRepository the
+// way RepositoryInfo is, so there's nothing to diff against without one of
+// these.
+type PlanOption func(*planOptions)
+
+type planOptions struct {
+	deployKey  *gitprovider.DeployKeyInfo
+	hook       *gitprovider.HookInfo
+	teamAccess *gitprovider.TeamAccessInfo
+}
+
+// WithDeployKeyPlan includes req's deploy key plan in the composed
+// ReconcilePlan returned by Plan.
+func WithDeployKeyPlan(req gitprovider.DeployKeyInfo) PlanOption {
+	return func(o *planOptions) { o.deployKey = &req }
+}
+
+// WithHookPlan includes req's webhook plan in the composed ReconcilePlan
+// returned by Plan.
+func WithHookPlan(req gitprovider.HookInfo) PlanOption {
+	return func(o *planOptions) { o.hook = &req }
+}
+
+// WithTeamAccessPlan includes req's team access plan in the composed
+// ReconcilePlan returned by Plan. It only has an effect on orgRepository,
+// since user repositories have no team access to plan.
+func WithTeamAccessPlan(req gitprovider.TeamAccessInfo) PlanOption {
+	return func(o *planOptions) { o.teamAccess = &req }
+}
+
+func resolvePlanOptions(opts []PlanOption) *planOptions {
+	o := &planOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// planSubResources folds the sub-resource plans requested via opts into
+// plan.SubResourcePlans, using whichever of deployKeys, hooks and teamAccess
+// is non-nil. teamAccess is nil for userRepository, which has no team
+// access client.
+func planSubResources(ctx context.Context, plan *gitprovider.ReconcilePlan, opts *planOptions, deployKeys *DeployKeyClient, hooks *RepositoryHookClient, teamAccess *TeamAccessClient) (*gitprovider.ReconcilePlan, error) {
+	if opts.deployKey != nil {
+		sub, err := deployKeys.Plan(ctx, *opts.deployKey)
+		if err != nil {
+			return nil, err
+		}
+		plan.SubResourcePlans = append(plan.SubResourcePlans, gitprovider.NamedReconcilePlan{Name: "DeployKey", Plan: *sub})
+	}
+	if opts.hook != nil {
+		sub, err := hooks.Plan(ctx, *opts.hook)
+		if err != nil {
+			return nil, err
+		}
+		plan.SubResourcePlans = append(plan.SubResourcePlans, gitprovider.NamedReconcilePlan{Name: "Hook", Plan: *sub})
+	}
+	if opts.teamAccess != nil && teamAccess != nil {
+		sub, err := teamAccess.Plan(ctx, *opts.teamAccess)
+		if err != nil {
+			return nil, err
+		}
+		plan.SubResourcePlans = append(plan.SubResourcePlans, gitprovider.NamedReconcilePlan{Name: "TeamAccess", Plan: *sub})
+	}
+	return plan, nil
+}
+
+func stringPtrEqual(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func stringPtrValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stash
+
+import (
+	"context"
+
+	"github.com/fluxcd/go-git-providers/gitprovider"
+)
+
+// Plan reports whether the given team would be granted, updated, or is
+// already at, the requested permission level, without granting or updating
+// anything. It lets a whole-repository Plan compose team access into its
+// sub-resource plans.
+//
+// Team access in Bitbucket Server is a project-group permission grant, not
+// group membership, so this compares against ListProjectGroups rather than
+// asking whether some user is a member of the team's group.
+func (c *TeamAccessClient) Plan(ctx context.Context, req gitprovider.TeamAccessInfo) (*gitprovider.ReconcilePlan, error) {
+	permissions, err := c.client.ListProjectGroups(ctx, c.ref.GetIdentity())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, permission := range permissions {
+		if permission.Group.Name != req.Name {
+			continue
+		}
+		if permission.Permission == string(req.Permission) {
+			return &gitprovider.ReconcilePlan{Action: gitprovider.ReconcileActionNoop}, nil
+		}
+		return &gitprovider.ReconcilePlan{Action: gitprovider.ReconcileActionUpdate}, nil
+	}
+	return &gitprovider.ReconcilePlan{Action: gitprovider.ReconcileActionCreate}, nil
+}
+
+// Plan reports whether the given deploy key would be created, updated, or is
+// already present, without creating or updating anything. It lets a
+// whole-repository Plan compose deploy keys into its sub-resource plans.
+func (c *DeployKeyClient) Plan(ctx context.Context, req gitprovider.DeployKeyInfo) (*gitprovider.ReconcilePlan, error) {
+	actual, err := c.Get(ctx, req.Name)
+	if err != nil {
+		if err == gitprovider.ErrNotFound {
+			return &gitprovider.ReconcilePlan{Action: gitprovider.ReconcileActionCreate}, nil
+		}
+		return nil, err
+	}
+
+	if actual.Get().Key == req.Key && actual.Get().ReadOnly == req.ReadOnly {
+		return &gitprovider.ReconcilePlan{Action: gitprovider.ReconcileActionNoop}, nil
+	}
+	return &gitprovider.ReconcilePlan{Action: gitprovider.ReconcileActionUpdate}, nil
+}
@@ -45,6 +45,14 @@ func newUserRepository(ctx *clientContext, apiObj *Repository, ref gitprovider.R
 			clientContext: ctx,
 			ref:           ref,
 		},
+		checks: &ChecksClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
+		hooks: &RepositoryHookClient{
+			clientContext: ctx,
+			ref:           ref,
+		},
 	}
 }
 
@@ -58,6 +66,8 @@ type userRepository struct {
 	branches     *BranchClient
 	pullRequests *PullRequestClient
 	commits      *CommitClient
+	checks       *ChecksClient
+	hooks        *RepositoryHookClient
 }
 
 func (r *userRepository) Branches() gitprovider.BranchClient {
@@ -113,29 +123,6 @@ func (r *userRepository) Update(ctx context.Context) error {
 
 }
 
-// Reconcile makes sure the desired state in this object (called "req" here) becomes
-// the actual state in the backing Git provider.
-
-// If req doesn't exist under the hood, it is created (actionTaken == true).
-// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
-// If req is already the actual state, this is a no-op (actionTaken == false).
-//
-// The internal API object will be overridden with the received server data if actionTaken == true.
-func (r *userRepository) Reconcile(ctx context.Context) (bool, error) {
-	_, actionTaken, err := r.c.Reconcile(ctx, r.ref.(gitprovider.UserRepositoryRef), repositoryFromAPI(&r.repository))
-
-	if err != nil {
-		// Log the error and return it
-		r.c.log.V(1).Error(err, "Error reconciling repository",
-			"org", r.Repository().GetIdentity(),
-			"repo", r.Repository().GetRepository(),
-			"actionTaken", actionTaken)
-		return actionTaken, err
-	}
-
-	return actionTaken, nil
-}
-
 // Delete deletes the current resource irreversibly.
 // ErrNotFound is returned if the resource doesn't exist anymore.
 func (r *userRepository) Delete(ctx context.Context) error {
@@ -168,30 +155,6 @@ func (r *orgRepository) TeamAccess() gitprovider.TeamAccessClient {
 	return r.teamAccess
 }
 
-// Reconcile makes sure the desired state in this object (called "req" here) becomes
-// the actual state in the backing Git provider.
-//
-// If req doesn't exist under the hood, it is created (actionTaken == true).
-// If req doesn't equal the actual state, the resource will be updated (actionTaken == true).
-// If req is already the actual state, this is a no-op (actionTaken == false).
-//
-// The internal API object will be overridden with the received server data if actionTaken == true.
-func (r *orgRepository) Reconcile(ctx context.Context) (bool, error) {
-	_, actionTaken, err := r.c.Reconcile(ctx, r.ref.(gitprovider.OrgRepositoryRef), repositoryFromAPI(&r.repository))
-
-	if err != nil {
-		// Log the error and return it
-		r.c.log.V(1).Error(err, "Error reconciling repository",
-			"org", r.Repository().GetIdentity(),
-			"repo", r.Repository().GetRepository(),
-			"actionTaken", actionTaken)
-		return actionTaken, err
-	}
-
-	return actionTaken, nil
-
-}
-
 // The internal API object will be overridden with the received server data.
 func (r *orgRepository) Update(ctx context.Context) error {
 	ref := r.ref.(gitprovider.OrgRepositoryRef)
@@ -18,6 +18,7 @@ package stash
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/go-logr/logr"
 
@@ -55,6 +56,10 @@ type stashClient interface {
 	// It returns the user if a member of a group/project or nil if not
 	GetGroupMember(ctx context.Context, groupID interface{}, userID interface{}) (*GroupMembers, error)
 
+	// HasGroupMember is a wrapper around GetGroupMember that reports whether userID is a
+	// member of groupID, without requiring callers to distinguish ErrNotFound from a real error.
+	HasGroupMember(ctx context.Context, groupID interface{}, userID interface{}) (bool, error)
+
 	// Project methods
 
 	// GetProject is a wrapper for "GET /rest/api/1.0/projects?filter={project}".
@@ -74,6 +79,58 @@ type stashClient interface {
 
 	//getLogger gets the logger
 	getLogger() logr.Logger
+
+	// Commit status methods
+
+	// CreateCommitStatus is a wrapper for "POST /rest/build-status/1.0/commits/{commitId}".
+	// This function handles HTTP error wrapping. The endpoint returns no body, so the
+	// posted status is returned and validated in place of a server result.
+	CreateCommitStatus(ctx context.Context, ownerID, repoSlug, commitSHA string, req *CommitStatus) (*CommitStatus, error)
+
+	// ListCommitStatuses is a wrapper for "GET /rest/build-status/1.0/commits/{commitId}".
+	// This function handles pagination, HTTP error wrapping, and validates the server result.
+	ListCommitStatuses(ctx context.Context, ownerID, repoSlug, commitSHA string) ([]*CommitStatus, error)
+
+	// Code Insights (checks) methods
+
+	// UpsertReport is a wrapper for "PUT .../commits/{commitId}/reports/{reportKey}".
+	// This function handles HTTP error wrapping, and validates the server result.
+	UpsertReport(ctx context.Context, ownerID, repoSlug, commitSHA string, req *Report) (*Report, error)
+
+	// DeleteReport is a wrapper for "DELETE .../commits/{commitId}/reports/{reportKey}".
+	// This function handles HTTP error wrapping.
+	DeleteReport(ctx context.Context, ownerID, repoSlug, commitSHA, reportKey string) error
+
+	// ListReports is a wrapper for "GET .../commits/{commitId}/reports".
+	// This function handles pagination, HTTP error wrapping, and validates the server result.
+	ListReports(ctx context.Context, ownerID, repoSlug, commitSHA string) ([]*Report, error)
+
+	// AddAnnotations is a wrapper for "POST .../commits/{commitId}/reports/{reportKey}/annotations".
+	// This function handles HTTP error wrapping. Callers are responsible for chunking to
+	// the API's 1000-annotation-per-call limit.
+	AddAnnotations(ctx context.Context, ownerID, repoSlug, commitSHA, reportKey string, annotations []*Annotation) error
+
+	// Webhook methods
+
+	// CreateHook is a wrapper for "POST /rest/api/1.0/projects/{projectKey}/repos/{repoSlug}/webhooks".
+	// This function handles HTTP error wrapping, and validates the server result.
+	CreateHook(ctx context.Context, ownerID, repoSlug string, req *Hook) (*Hook, error)
+
+	// GetHook is a wrapper for "GET .../webhooks/{webhookId}".
+	// This function handles HTTP error wrapping, and validates the server result.
+	GetHook(ctx context.Context, ownerID, repoSlug, hookID string) (*Hook, error)
+
+	// ListHooks is a wrapper for "GET .../webhooks".
+	// This function handles pagination, HTTP error wrapping, and validates the server result.
+	ListHooks(ctx context.Context, ownerID, repoSlug string) ([]*Hook, error)
+
+	// UpdateHook is a wrapper for "PUT .../webhooks/{webhookId}".
+	// This function handles HTTP error wrapping, and validates the server result.
+	UpdateHook(ctx context.Context, ownerID, repoSlug, hookID string, req *Hook) (*Hook, error)
+
+	// DeleteHook is a wrapper for "DELETE .../webhooks/{webhookId}".
+	// This function handles HTTP error wrapping.
+	DeleteHook(ctx context.Context, ownerID, repoSlug, hookID string) error
 }
 
 // stashClientImpl is a wrapper around httpclient.ReqResp and Client
@@ -158,7 +215,32 @@ func (c *stashClientImpl) ListGroups(ctx context.Context) ([]*Group, error) {
 }
 
 func (c *stashClientImpl) GetGroupMember(ctx context.Context, groupID interface{}, userID interface{}) (*GroupMembers, error) {
-	return nil, gitprovider.ErrNoProviderSupport
+	groupMembers := NewStashGroupMembers(c)
+	apiObj, err := groupMembers.GetFiltered(ctx, groupID.(string), userID.(string))
+	if err != nil {
+		return nil, err
+	}
+	if apiObj == nil || len(apiObj.Values) == 0 {
+		return nil, gitprovider.ErrNotFound
+	}
+	// Validate the API objects
+	for _, user := range apiObj.Values {
+		if err := validateUserAPI(user); err != nil {
+			return nil, err
+		}
+	}
+	return apiObj, nil
+}
+
+func (c *stashClientImpl) HasGroupMember(ctx context.Context, groupID interface{}, userID interface{}) (bool, error) {
+	_, err := c.GetGroupMember(ctx, groupID, userID)
+	if err != nil {
+		if err == gitprovider.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
 
 func (c *stashClientImpl) ListGroupMembers(ctx context.Context, groupID interface{}) ([]*User, error) {
@@ -240,3 +322,145 @@ func (c *stashClientImpl) ListProjectGroups(ctx context.Context, projectName str
 	}
 	return apiObjs, nil
 }
+
+func (c *stashClientImpl) CreateCommitStatus(ctx context.Context, ownerID, repoSlug, commitSHA string, req *CommitStatus) (*CommitStatus, error) {
+	commitStatuses := NewStashCommitStatuses(c, ownerID, repoSlug)
+	apiObj, err := commitStatuses.Create(ctx, commitSHA, req)
+	if err != nil {
+		return nil, err
+	}
+	// Validate the API objects
+	if err := validateCommitStatusAPI(apiObj); err != nil {
+		return nil, err
+	}
+	return apiObj, nil
+}
+
+func (c *stashClientImpl) ListCommitStatuses(ctx context.Context, ownerID, repoSlug, commitSHA string) ([]*CommitStatus, error) {
+	commitStatuses := NewStashCommitStatuses(c, ownerID, repoSlug)
+	apiObjs := []*CommitStatus{}
+	opts := &ListOptions{}
+	err := allPages(opts, func() (*Paging, error) {
+		// GET /commits/{commitId}
+		paging, listErr := commitStatuses.List(ctx, commitSHA, opts)
+		apiObjs = append(apiObjs, commitStatuses.getCommitStatuses()...)
+		return paging, listErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Validate the API objects
+	for _, apiObj := range apiObjs {
+		if err := validateCommitStatusAPI(apiObj); err != nil {
+			return nil, err
+		}
+	}
+	return apiObjs, nil
+}
+
+func (c *stashClientImpl) UpsertReport(ctx context.Context, ownerID, repoSlug, commitSHA string, req *Report) (*Report, error) {
+	reports := NewStashReports(c, ownerID, repoSlug)
+	apiObj, err := reports.Upsert(ctx, commitSHA, req)
+	if err != nil {
+		return nil, err
+	}
+	// Validate the API objects
+	if err := validateReportAPI(apiObj); err != nil {
+		return nil, err
+	}
+	return apiObj, nil
+}
+
+func (c *stashClientImpl) DeleteReport(ctx context.Context, ownerID, repoSlug, commitSHA, reportKey string) error {
+	reports := NewStashReports(c, ownerID, repoSlug)
+	return reports.Delete(ctx, commitSHA, reportKey)
+}
+
+func (c *stashClientImpl) ListReports(ctx context.Context, ownerID, repoSlug, commitSHA string) ([]*Report, error) {
+	reports := NewStashReports(c, ownerID, repoSlug)
+	apiObjs := []*Report{}
+	opts := &ListOptions{}
+	err := allPages(opts, func() (*Paging, error) {
+		// GET /reports
+		paging, listErr := reports.List(ctx, commitSHA, opts)
+		apiObjs = append(apiObjs, reports.getReports()...)
+		return paging, listErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Validate the API objects
+	for _, apiObj := range apiObjs {
+		if err := validateReportAPI(apiObj); err != nil {
+			return nil, err
+		}
+	}
+	return apiObjs, nil
+}
+
+func (c *stashClientImpl) AddAnnotations(ctx context.Context, ownerID, repoSlug, commitSHA, reportKey string, annotations []*Annotation) error {
+	reports := NewStashReports(c, ownerID, repoSlug)
+	return reports.AddAnnotations(ctx, commitSHA, reportKey, annotations)
+}
+
+func (c *stashClientImpl) CreateHook(ctx context.Context, ownerID, repoSlug string, req *Hook) (*Hook, error) {
+	hooks := NewStashHooks(c, ownerID, repoSlug)
+	apiObj, err := hooks.Create(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateHookAPI(apiObj); err != nil {
+		return nil, err
+	}
+	return apiObj, nil
+}
+
+func (c *stashClientImpl) GetHook(ctx context.Context, ownerID, repoSlug, hookID string) (*Hook, error) {
+	hooks := NewStashHooks(c, ownerID, repoSlug)
+	apiObj, err := hooks.Get(ctx, hookID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateHookAPI(apiObj); err != nil {
+		return nil, err
+	}
+	return apiObj, nil
+}
+
+func (c *stashClientImpl) ListHooks(ctx context.Context, ownerID, repoSlug string) ([]*Hook, error) {
+	hooks := NewStashHooks(c, ownerID, repoSlug)
+	apiObjs := []*Hook{}
+	opts := &ListOptions{}
+	err := allPages(opts, func() (*Paging, error) {
+		// GET /webhooks
+		paging, listErr := hooks.List(ctx, opts)
+		apiObjs = append(apiObjs, hooks.getHooks()...)
+		return paging, listErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, apiObj := range apiObjs {
+		if err := validateHookAPI(apiObj); err != nil {
+			return nil, err
+		}
+	}
+	return apiObjs, nil
+}
+
+func (c *stashClientImpl) UpdateHook(ctx context.Context, ownerID, repoSlug, hookID string, req *Hook) (*Hook, error) {
+	hooks := NewStashHooks(c, ownerID, repoSlug)
+	apiObj, err := hooks.Update(ctx, hookID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateHookAPI(apiObj); err != nil {
+		return nil, err
+	}
+	return apiObj, nil
+}
+
+func (c *stashClientImpl) DeleteHook(ctx context.Context, ownerID, repoSlug, hookID string) error {
+	hooks := NewStashHooks(c, ownerID, repoSlug)
+	return hooks.Delete(ctx, hookID)
+}